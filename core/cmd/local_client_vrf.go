@@ -1,9 +1,12 @@
 package cmd
 
 import (
+	"bytes"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"net/http"
 	"os"
 
 	"github.com/pkg/errors"
@@ -19,14 +22,66 @@ func vRFKeyStore(cli *Client) *store.VRFKeyStore {
 	return cli.AppFactory.NewApplication(cli.Config).GetStore().VRFKeyStore
 }
 
+// vrfKeyStoreForCommand returns the VRFKeyStore a given CLI invocation should
+// use: ordinarily the app's own, DB-backed, store, but retargeted at a
+// different store.VRFKeyStoreBackend if --backend (and any backend-specific
+// flags) were passed. readOnly must be true only for commands that never
+// write through the backend (ListKeys, ExportVRFKey), since --backend hsm is
+// rejected otherwise.
+func vrfKeyStoreForCommand(cli *Client, c *clipkg.Context, readOnly bool) (*store.VRFKeyStore, error) {
+	ks := vRFKeyStore(cli)
+	if !c.IsSet("backend") {
+		return ks, nil
+	}
+	switch c.String("backend") {
+	case "db":
+		return ks, nil
+	case "file":
+		if !c.IsSet("backend-path") {
+			return nil, fmt.Errorf("must specify --backend-path with --backend file")
+		}
+		backend, err := store.NewFileVRFKeyStoreBackend(c.String("backend-path"))
+		if err != nil {
+			return nil, err
+		}
+		return ks.WithBackend(backend), nil
+	case "hsm":
+		if !readOnly {
+			return nil, fmt.Errorf("--backend hsm is read-only; it cannot be selected for this command")
+		}
+		if !c.IsSet("backend-addr") {
+			return nil, fmt.Errorf("must specify --backend-addr with --backend hsm")
+		}
+		backend, err := store.NewHSMVRFKeyStoreBackend(c.String("backend-addr"), &tls.Config{})
+		if err != nil {
+			return nil, err
+		}
+		return ks.WithBackend(backend), nil
+	default:
+		return nil, fmt.Errorf("unknown --backend %q, must be one of db, file, hsm", c.String("backend"))
+	}
+}
+
 // CreateVRFKey creates a key in the VRF keystore, protected by the password in
-// the password file
+// the password file. By default the key is encrypted with scrypt, using the
+// same cost parameters as always; pass --kdf bcrypt (and, optionally,
+// --bcrypt-cost) to use bcrypt instead, or --scrypt-n/--scrypt-r/--scrypt-p
+// to tune the scrypt cost parameters.
 func (cli *Client) CreateVRFKey(c *clipkg.Context) error {
 	password, err := getPassword(c)
 	if err != nil {
 		return err
 	}
-	key, err := vRFKeyStore(cli).CreateKey(string(password))
+	defer password.Zero()
+	opts, err := vrfKeyOptionsFromFlags(c)
+	if err != nil {
+		return err
+	}
+	ks, err := vrfKeyStoreForCommand(cli, c, false)
+	if err != nil {
+		return err
+	}
+	key, err := ks.CreateKey(password, opts...)
 	if err != nil {
 		return errors.Wrapf(err, "while creating new account")
 	}
@@ -52,22 +107,56 @@ func (cli *Client) CreateAndExportWeakVRFKey(c *clipkg.Context) error {
 	if err != nil {
 		return err
 	}
-	key, err := vRFKeyStore(cli).CreateWeakInMemoryEncryptedKeyXXXTestingOnly(
-		string(password))
+	defer password.Zero()
+	key, err := vRFKeyStore(cli).CreateWeakInMemoryEncryptedKeyXXXTestingOnly(password)
 	if err != nil {
 		return errors.Wrapf(err, "while creating testing key")
 	}
+	defer key.Forget()
 	if !c.IsSet("file") || !noFileToOverwrite(c.String("file")) {
 		errmsg := "must specify path to key file which does not already exist"
 		fmt.Println(errmsg)
 		return fmt.Errorf(errmsg)
 	}
 	fmt.Println("Don't use this key for anything sensitive!")
-	return key.WriteToDisk(c.String("file"))
+	return key.WriteToDisk(c.String("file"), password)
 }
 
-// getPassword retrieves the password from the file specified on the CL, or errors
-func getPassword(c *clipkg.Context) ([]byte, error) {
+// vrfKeyOptionsFromFlags builds the store.KeyOption list implied by the
+// --kdf, --scrypt-n, --scrypt-r, --scrypt-p and --bcrypt-cost flags, if any
+// of them were set on c.
+func vrfKeyOptionsFromFlags(c *clipkg.Context) ([]store.KeyOption, error) {
+	var opts []store.KeyOption
+	if c.IsSet("kdf") {
+		kdf := vrfkey.KDF(c.String("kdf"))
+		if err := kdf.Validate(); err != nil {
+			return nil, err
+		}
+		opts = append(opts, store.WithKDF(kdf))
+	}
+	if c.IsSet("scrypt-n") || c.IsSet("scrypt-r") || c.IsSet("scrypt-p") {
+		p := vrfkey.DefaultScryptParams
+		if c.IsSet("scrypt-n") {
+			p.N = c.Int("scrypt-n")
+		}
+		if c.IsSet("scrypt-r") {
+			p.R = c.Int("scrypt-r")
+		}
+		if c.IsSet("scrypt-p") {
+			p.P = c.Int("scrypt-p")
+		}
+		opts = append(opts, store.WithScryptParams(p))
+	}
+	if c.IsSet("bcrypt-cost") {
+		opts = append(opts, store.WithBcryptParams(vrfkey.BcryptParams{Cost: c.Int("bcrypt-cost")}))
+	}
+	return opts, nil
+}
+
+// getPassword retrieves the password from the file specified on the CL, or
+// errors. Callers are responsible for calling Zero on the result once they
+// are done with it.
+func getPassword(c *clipkg.Context) (utils.SecretBytes, error) {
 	if !c.IsSet("password") {
 		return nil, fmt.Errorf("must specify password file")
 	}
@@ -76,12 +165,12 @@ func getPassword(c *clipkg.Context) ([]byte, error) {
 		return nil, errors.Wrapf(err, "could not read password from file %s",
 			c.String("password"))
 	}
-	return []byte(rawPassword), nil
+	return utils.SecretBytes(rawPassword), nil
 }
 
 // getPasswordAndKeyFile retrieves the password and key json from the files
 // specified on the CL, or errors
-func getPasswordAndKeyFile(c *clipkg.Context) (password []byte, keyjson []byte, err error) {
+func getPasswordAndKeyFile(c *clipkg.Context) (password utils.SecretBytes, keyjson []byte, err error) {
 	password, err = getPassword(c)
 	if err != nil {
 		return nil, nil, err
@@ -97,13 +186,42 @@ func getPasswordAndKeyFile(c *clipkg.Context) (password []byte, keyjson []byte,
 	return password, keyjson, nil
 }
 
-// ImportVRFKey reads a file into an EncryptedSecretKey in the db
+// keyFileFormat returns the --format flag's value, defaulting to the native
+// "chainlink" format if it was not given.
+func keyFileFormat(c *clipkg.Context) (string, error) {
+	if !c.IsSet("format") {
+		return "chainlink", nil
+	}
+	switch f := c.String("format"); f {
+	case "chainlink", "web3v3":
+		return f, nil
+	default:
+		return "", fmt.Errorf(`unknown --format %q, must be "chainlink" or "web3v3"`, f)
+	}
+}
+
+// ImportVRFKey reads a file into an EncryptedSecretKey in the db. By default
+// it expects the native chainlink key JSON; pass --format web3v3 to import
+// an Ethereum Web3 Secret Storage v3 key instead (e.g. one exported from
+// geth, clef or MyCrypto).
 func (cli *Client) ImportVRFKey(c *clipkg.Context) error {
+	format, err := keyFileFormat(c)
+	if err != nil {
+		return err
+	}
+	if format == "web3v3" {
+		return cli.importWeb3v3VRFKey(c)
+	}
 	password, keyjson, err := getPasswordAndKeyFile(c)
 	if err != nil {
 		return err
 	}
-	if err := vRFKeyStore(cli).Import(keyjson, string(password)); err != nil {
+	defer password.Zero()
+	ks, err := vrfKeyStoreForCommand(cli, c, false)
+	if err != nil {
+		return err
+	}
+	if err := ks.Import(keyjson, password); err != nil {
 		if err == store.MatchingVRFKeyError {
 			fmt.Println(`The database already has an entry for that public key.`)
 			var key struct{ PublicKey string }
@@ -123,10 +241,54 @@ func (cli *Client) ImportVRFKey(c *clipkg.Context) error {
 	return nil
 }
 
+// importWeb3v3VRFKey decrypts a Web3 Secret Storage v3 key, re-derives its
+// VRF public key from the recovered scalar (the v3 format has no field for
+// it), re-encrypts it in the native format, and imports it as usual.
+func (cli *Client) importWeb3v3VRFKey(c *clipkg.Context) error {
+	password, keyjson, err := getPasswordAndKeyFile(c)
+	if err != nil {
+		return err
+	}
+	defer password.Zero()
+	key, err := vrfkey.DecryptWeb3v3(keyjson, password)
+	if err != nil {
+		return errors.Wrap(err, "while decrypting web3v3 VRF key")
+	}
+	defer key.Forget()
+	enc, err := key.Encrypt(password, vrfkey.KDFScrypt, vrfkey.DefaultScryptParams, vrfkey.DefaultBcryptParams)
+	if err != nil {
+		return errors.Wrap(err, "while re-encrypting imported VRF key")
+	}
+	nativeJSON, err := json.Marshal(enc)
+	if err != nil {
+		return errors.Wrap(err, "while marshaling imported VRF key")
+	}
+	ks, err := vrfKeyStoreForCommand(cli, c, false)
+	if err != nil {
+		return err
+	}
+	if err := ks.Import(nativeJSON, password); err != nil {
+		if err == store.MatchingVRFKeyError {
+			fmt.Println("The database already has an entry for public key " + key.PublicKey.String())
+		}
+		return err
+	}
+	return nil
+}
+
 // ExportVRFKey saves encrypted copy of VRF key with given public key to
 // requested file path. If there is more than one encrypted copy, the ones past
-// the first are saved with extensions '.1', '.2', etc.
+// the first are saved with extensions '.1', '.2', etc. Pass --format web3v3
+// to save it as an Ethereum Web3 Secret Storage v3 key instead, for use with
+// geth, clef or MyCrypto.
 func (cli *Client) ExportVRFKey(c *clipkg.Context) error {
+	format, err := keyFileFormat(c)
+	if err != nil {
+		return err
+	}
+	if format == "web3v3" {
+		return cli.exportWeb3v3VRFKey(c)
+	}
 	enckeys, err := getKeys(cli, c)
 	if err != nil {
 		return err
@@ -147,13 +309,55 @@ func (cli *Client) ExportVRFKey(c *clipkg.Context) error {
 	return nil
 }
 
+// exportWeb3v3VRFKey decrypts the native-format key with the given password,
+// then re-encrypts it, under the same password, as a Web3 Secret Storage v3
+// key. It requires --password in addition to --publicKey and --file, since
+// producing web3v3 ciphertext means first recovering the plaintext scalar.
+func (cli *Client) exportWeb3v3VRFKey(c *clipkg.Context) error {
+	if !c.IsSet("file") {
+		return fmt.Errorf("must specify file to export to")
+	}
+	password, err := getPassword(c)
+	if err != nil {
+		return err
+	}
+	defer password.Zero()
+	enckeys, err := getKeys(cli, c)
+	if err != nil {
+		return err
+	}
+	if len(enckeys) == 0 {
+		return fmt.Errorf("no matching VRF key found")
+	}
+	var enc vrfkey.EncryptedVRFKey
+	if err := json.Unmarshal(enckeys[0], &enc); err != nil {
+		return errors.Wrap(err, "while parsing stored VRF key")
+	}
+	seed, err := enc.Decrypt(password)
+	if err != nil {
+		return errors.Wrap(err, "while decrypting VRF key for export")
+	}
+	defer seed.Zero()
+	key := vrfkey.NewPrivateKeyFromSeed(enc.PublicKey, seed)
+	defer key.Forget()
+	keyjson, err := key.EncryptWeb3v3(password, vrfkey.DefaultScryptParams)
+	if err != nil {
+		return errors.Wrap(err, "while encoding VRF key as web3v3")
+	}
+	return ioutil.WriteFile(c.String("file"), keyjson, 0644)
+}
+
 // getKeys retrieves the keys for an ExportVRFKey request
 func getKeys(cli *Client, c *clipkg.Context) ([][]byte, error) {
 	publicKey, err := getPublicKey(c)
 	if err != nil {
 		return nil, err
 	}
-	enckeys, err := vRFKeyStore(cli).Export(publicKey)
+	ks, err := vrfKeyStoreForCommand(cli, c, true)
+	if err != nil {
+		return nil, err
+	}
+	enckeys, err := ks.Export(publicKey)
 	if err != nil { // Tolerate errors here, in case some keys were retrievable
 		logger.Infow("while retrieving keys with matching public key", publicKey, err)
 	}
@@ -169,7 +373,11 @@ func (cli *Client) DeleteVRFKey(c *clipkg.Context) error {
 	if err != nil {
 		return err
 	}
-	if err := vRFKeyStore(cli).Delete(publicKey); err != nil {
+	ks, err := vrfKeyStoreForCommand(cli, c, false)
+	if err != nil {
+		return err
+	}
+	if err := ks.Delete(publicKey); err != nil {
 		if err == store.AttemptToDeleteNonExistentKeyFromDB {
 			fmt.Println("There is already no entry in the DB for " + publicKey.String())
 		}
@@ -191,7 +399,11 @@ func getPublicKey(c *clipkg.Context) (*vrfkey.PublicKey, error) {
 
 // ListKeys Lists the keys in the db
 func (cli *Client) ListKeys(c *clipkg.Context) error {
-	keys, err := vRFKeyStore(cli).ListKeys()
+	ks, err := vrfKeyStoreForCommand(cli, c, true)
+	if err != nil {
+		return err
+	}
+	keys, err := ks.ListKeys()
 	if err != nil {
 		return err
 	}
@@ -202,10 +414,122 @@ func (cli *Client) ListKeys(c *clipkg.Context) error {
 	return nil
 }
 
-// Forget removes the key from the in-memory key store, but leaves it in the db
+// Forget removes one key, or every key (--all), from the *running* node's
+// in-memory VRF keystore, but leaves it in the db. Since this CLI runs in a
+// process independent from the node, it cannot poke at that process's memory
+// directly; instead it calls the node's authenticated admin API, so an
+// operator can lock a key without restarting the node.
 func (cli *Client) Forget(c *clipkg.Context) error {
+	if !c.Bool("all") && !c.IsSet("publicKey") {
+		return fmt.Errorf("must specify either -pk <public key> or --all")
+	}
+	request := struct {
+		PublicKey string `json:"publicKey,omitempty"`
+		All       bool   `json:"all,omitempty"`
+	}{All: c.Bool("all")}
+	if c.IsSet("publicKey") {
+		publicKey, err := getPublicKey(c)
+		if err != nil {
+			return err
+		}
+		request.PublicKey = publicKey.String()
+	}
+	body, err := json.Marshal(request)
+	if err != nil {
+		return errors.Wrap(err, "while building forget request")
+	}
+	resp, err := cli.HTTP.Post("/v2/vrf_keys/forget", bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "while calling node to forget VRF key(s)")
+	}
+	defer resp.Body.Close()
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrap(err, "while reading forget response")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("error forgetting VRF key(s): %s", respBody)
+	}
+	fmt.Println("VRF key(s) locked")
 	return nil
 }
 func noFileToOverwrite(path string) bool {
 	return os.IsNotExist(utils.JustError(os.Stat(path)))
 }
+
+// getArchivePassword retrieves the archive passphrase from the file
+// specified via --archive-password. This is deliberately a separate flag
+// from --password: the archive passphrase protects the outer envelope of an
+// ExportAllVRFKeys backup, not any individual key within it.
+func getArchivePassword(c *clipkg.Context) (utils.SecretBytes, error) {
+	if !c.IsSet("archive-password") {
+		return nil, fmt.Errorf("must specify archive password file")
+	}
+	rawPassword, err := passwordFromFile(c.String("archive-password"))
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not read archive password from file %s",
+			c.String("archive-password"))
+	}
+	return utils.SecretBytes(rawPassword), nil
+}
+
+// ExportAllVRFKeys bundles every VRF key known to the keystore into a single
+// encrypted archive file, for disaster-recovery backup in one shot, rather
+// than calling export once per key.
+func (cli *Client) ExportAllVRFKeys(c *clipkg.Context) error {
+	if !c.IsSet("file") {
+		return fmt.Errorf("must specify file to export to")
+	}
+	archivePassword, err := getArchivePassword(c)
+	if err != nil {
+		return err
+	}
+	defer archivePassword.Zero()
+	ks, err := vrfKeyStoreForCommand(cli, c, false)
+	if err != nil {
+		return err
+	}
+	archive, err := ks.ExportAll(archivePassword)
+	if err != nil {
+		return errors.Wrap(err, "while exporting VRF keystore")
+	}
+	if err := ioutil.WriteFile(c.String("file"), archive, 0600); err != nil {
+		return errors.Wrapf(err, "while writing archive to %s", c.String("file"))
+	}
+	fmt.Printf("Exported VRF keystore to %s\n", c.String("file"))
+	return nil
+}
+
+// ImportAllVRFKeys restores every key bundled in a file produced by
+// ExportAllVRFKeys. Keys whose public key already exists in the keystore are
+// left untouched and reported as skipped, unless --overwrite is passed.
+func (cli *Client) ImportAllVRFKeys(c *clipkg.Context) error {
+	if !c.IsSet("file") {
+		return fmt.Errorf("must specify file to import from")
+	}
+	archivePassword, err := getArchivePassword(c)
+	if err != nil {
+		return err
+	}
+	defer archivePassword.Zero()
+	archive, err := ioutil.ReadFile(c.String("file"))
+	if err != nil {
+		return errors.Wrapf(err, "failed to read file %s", c.String("file"))
+	}
+	ks, err := vrfKeyStoreForCommand(cli, c, false)
+	if err != nil {
+		return err
+	}
+	results, err := ks.ImportAll(archive, archivePassword, c.Bool("overwrite"))
+	if err != nil {
+		return errors.Wrap(err, "while importing VRF keystore archive")
+	}
+	for _, result := range results {
+		if result.Imported {
+			fmt.Printf("%s: imported\n", result.PublicKey)
+		} else {
+			fmt.Printf("%s: skipped (%s)\n", result.PublicKey, result.Reason)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,19 @@
+// Package logger provides a thin wrapper around a structured, leveled logger
+// used throughout the node.
+package logger
+
+import (
+	"go.uber.org/zap"
+)
+
+var sugared = zap.NewExample().Sugar()
+
+// Infow logs msg and the given alternating key/value pairs at info level.
+func Infow(msg string, keysAndValues ...interface{}) {
+	sugared.Infow(msg, keysAndValues...)
+}
+
+// Errorw logs msg and the given alternating key/value pairs at error level.
+func Errorw(msg string, keysAndValues ...interface{}) {
+	sugared.Errorw(msg, keysAndValues...)
+}
@@ -0,0 +1,80 @@
+package vrfkey
+
+import (
+	"crypto/rand"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/nacl/secretbox"
+
+	"chainlink/core/utils"
+)
+
+// EncryptedArchive is the on-disk format of a full-keystore backup: the
+// concatenation of every key's encrypted JSON, tarred up and then encrypted
+// again under a single archive passphrase, distinct from any individual
+// key's passphrase. Its KDF metadata is self-describing, exactly like
+// EncryptedVRFKey's.
+type EncryptedArchive struct {
+	KDF          KDF
+	ScryptParams *ScryptParams `json:",omitempty"`
+	BcryptParams *BcryptParams `json:",omitempty"`
+	Salt         []byte
+	Nonce        []byte
+	Ciphertext   []byte
+}
+
+// EncryptArchive encrypts plaintext (ordinarily a tar of every key in a
+// VRFKeyStore) under auth, the archive passphrase.
+func EncryptArchive(plaintext []byte, auth utils.SecretBytes, kdf KDF, scryptParams ScryptParams, bcryptParams BcryptParams) ([]byte, error) {
+	if err := kdf.Validate(); err != nil {
+		return nil, err
+	}
+	salt, err := newSalt(32)
+	if err != nil {
+		return nil, err
+	}
+	a := &EncryptedArchive{KDF: kdf, Salt: salt}
+	if kdf == KDFScrypt {
+		a.ScryptParams = &scryptParams
+	} else {
+		a.BcryptParams = &bcryptParams
+	}
+	key, err := deriveSymmetricKey(auth, salt, kdf, a.ScryptParams, a.BcryptParams)
+	if err != nil {
+		return nil, err
+	}
+	defer key.Zero()
+	var keyArray [32]byte
+	copy(keyArray[:], key)
+	var nonce [24]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return nil, errors.Wrap(err, "while generating nonce")
+	}
+	a.Nonce = nonce[:]
+	a.Ciphertext = secretbox.Seal(nil, plaintext, &nonce, &keyArray)
+	return json.Marshal(a)
+}
+
+// DecryptArchive recovers the plaintext tar protected by archivejson, given
+// the archive passphrase auth.
+func DecryptArchive(archivejson []byte, auth utils.SecretBytes) ([]byte, error) {
+	var a EncryptedArchive
+	if err := json.Unmarshal(archivejson, &a); err != nil {
+		return nil, errors.Wrap(err, "while parsing VRF archive JSON")
+	}
+	key, err := deriveSymmetricKey(auth, a.Salt, a.KDF, a.ScryptParams, a.BcryptParams)
+	if err != nil {
+		return nil, err
+	}
+	defer key.Zero()
+	var keyArray [32]byte
+	copy(keyArray[:], key)
+	var nonce [24]byte
+	copy(nonce[:], a.Nonce)
+	plaintext, ok := secretbox.Open(nil, a.Ciphertext, &nonce, &keyArray)
+	if !ok {
+		return nil, errors.New("could not decrypt VRF archive: incorrect passphrase or corrupted archive file")
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,138 @@
+package vrfkey
+
+import (
+	"crypto/rand"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+
+	"chainlink/core/utils"
+)
+
+// EncryptedVRFKey is the JSON format in which VRF keys are persisted to the DB
+// and to disk. It carries enough KDF metadata to be decrypted on its own,
+// without reference to any global config, so that keys created under
+// different cost profiles can be freely mixed in the same keystore.
+type EncryptedVRFKey struct {
+	PublicKey PublicKey
+	VRFKey    struct {
+		KDF          KDF
+		ScryptParams *ScryptParams `json:",omitempty"`
+		BcryptParams *BcryptParams `json:",omitempty"`
+		Salt         []byte
+		Nonce        []byte
+		Ciphertext   []byte
+	}
+}
+
+const keyLen = 32 // secretbox key size
+
+// newSalt returns a fresh, cryptographically random salt of the given length.
+func newSalt(n int) ([]byte, error) {
+	salt := make([]byte, n)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, errors.Wrap(err, "while generating salt")
+	}
+	return salt, nil
+}
+
+// deriveKey stretches auth into a keyLen-byte symmetric key, via the KDF and
+// parameters recorded on e.
+func (e *EncryptedVRFKey) deriveKey(auth utils.SecretBytes) (utils.SecretBytes, error) {
+	return deriveSymmetricKey(auth, e.VRFKey.Salt, e.VRFKey.KDF, e.VRFKey.ScryptParams, e.VRFKey.BcryptParams)
+}
+
+// deriveSymmetricKey stretches auth into a keyLen-byte symmetric key via kdf
+// (falling back to the relevant Default*Params if scryptParams/bcryptParams
+// is nil), salted with salt. Shared by EncryptedVRFKey and EncryptedArchive,
+// which both protect their payload the same way.
+func deriveSymmetricKey(auth utils.SecretBytes, salt []byte, kdf KDF, scryptParams *ScryptParams, bcryptParams *BcryptParams) (utils.SecretBytes, error) {
+	switch kdf {
+	case KDFScrypt:
+		p := scryptParams
+		if p == nil {
+			p = &DefaultScryptParams
+		}
+		key, err := scrypt.Key(auth, salt, p.N, p.R, p.P, keyLen)
+		return utils.SecretBytes(key), err
+	case KDFBcrypt:
+		p := bcryptParams
+		if p == nil {
+			p = &DefaultBcryptParams
+		}
+		// bcrypt.GenerateFromPassword is not usable here: it salts itself with
+		// fresh randomness on every call, so the value it produces at Encrypt
+		// time can never be reproduced at Decrypt time from (auth, salt, cost)
+		// alone. Treat the bcrypt cost as a scrypt work-factor exponent
+		// instead - this keeps "bcrypt" a distinct, low-memory cost profile
+		// from the scrypt one, while still being a deterministic KDF.
+		key, err := scrypt.Key(auth, salt, 1<<uint(p.Cost), 1, 1, keyLen)
+		return utils.SecretBytes(key), err
+	default:
+		return nil, kdf.Validate()
+	}
+}
+
+// encryptedVRFKey encrypts seed (the raw VRF secret scalar) under auth, using
+// kdf and its associated parameters, and associates it with publicKey.
+func encryptedVRFKey(publicKey PublicKey, seed utils.SecretBytes, auth utils.SecretBytes, kdf KDF, scryptParams ScryptParams, bcryptParams BcryptParams) (*EncryptedVRFKey, error) {
+	if err := kdf.Validate(); err != nil {
+		return nil, err
+	}
+	salt, err := newSalt(32)
+	if err != nil {
+		return nil, err
+	}
+	e := &EncryptedVRFKey{PublicKey: publicKey}
+	e.VRFKey.KDF = kdf
+	e.VRFKey.Salt = salt
+	if kdf == KDFScrypt {
+		e.VRFKey.ScryptParams = &scryptParams
+	} else {
+		e.VRFKey.BcryptParams = &bcryptParams
+	}
+	key, err := e.deriveKey(auth)
+	if err != nil {
+		return nil, err
+	}
+	defer key.Zero()
+	var keyArray [32]byte
+	copy(keyArray[:], key)
+	defer utils.SecretBytes(keyArray[:]).Zero()
+	var nonce [24]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return nil, errors.Wrap(err, "while generating nonce")
+	}
+	e.VRFKey.Nonce = nonce[:]
+	e.VRFKey.Ciphertext = secretbox.Seal(nil, seed, &nonce, &keyArray)
+	return e, nil
+}
+
+// Decrypt recovers the raw VRF secret scalar protected by e, given the
+// passphrase auth which was used to encrypt it.
+func (e *EncryptedVRFKey) Decrypt(auth utils.SecretBytes) (utils.SecretBytes, error) {
+	key, err := e.deriveKey(auth)
+	if err != nil {
+		return nil, err
+	}
+	defer key.Zero()
+	var keyArray [32]byte
+	copy(keyArray[:], key)
+	defer utils.SecretBytes(keyArray[:]).Zero()
+	var nonce [24]byte
+	copy(nonce[:], e.VRFKey.Nonce)
+	seed, ok := secretbox.Open(nil, e.VRFKey.Ciphertext, &nonce, &keyArray)
+	if !ok {
+		return nil, errors.New("could not decrypt VRF key: incorrect password or corrupted key file")
+	}
+	return utils.SecretBytes(seed), nil
+}
+
+// toJSON marshals e with the default struct-tag based encoding
+// (EncryptedVRFKey defines no MarshalJSON or UnmarshalJSON of its own). This
+// is the on-disk wire format and must stay backwards-compatible.
+func (e *EncryptedVRFKey) toJSON() ([]byte, error) {
+	return json.Marshal(e)
+}
@@ -0,0 +1,45 @@
+package vrfkey
+
+import (
+	"testing"
+
+	"chainlink/core/utils"
+)
+
+func TestEncryptedVRFKey_BcryptRoundTrip(t *testing.T) {
+	key, err := NewPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer key.Forget()
+	auth := utils.SecretBytes("p4ssword")
+	// A small Cost keeps the test fast; what matters is that Decrypt
+	// reproduces the same key Encrypt derived, not the actual work factor.
+	enc, err := key.Encrypt(auth, KDFBcrypt, DefaultScryptParams, BcryptParams{Cost: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	seed, err := enc.Decrypt(auth)
+	if err != nil {
+		t.Fatalf("could not decrypt a KDFBcrypt key with its own passphrase: %v", err)
+	}
+	defer seed.Zero()
+	if string(seed) != string(key.k) {
+		t.Fatal("decrypted KDFBcrypt seed does not match the original secret scalar")
+	}
+}
+
+func TestEncryptedVRFKey_BcryptWrongPassword(t *testing.T) {
+	key, err := NewPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer key.Forget()
+	enc, err := key.Encrypt(utils.SecretBytes("p4ssword"), KDFBcrypt, DefaultScryptParams, BcryptParams{Cost: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := enc.Decrypt(utils.SecretBytes("wrong")); err == nil {
+		t.Fatal("expected an error decrypting a KDFBcrypt key with the wrong passphrase")
+	}
+}
@@ -0,0 +1,55 @@
+package vrfkey
+
+import "fmt"
+
+// KDF identifies which key-derivation function was used (or should be used)
+// to stretch a passphrase into the symmetric key that encrypts a VRF secret
+// key on disk/in the DB.
+type KDF string
+
+const (
+	// KDFScrypt is the historical default, and remains the default today.
+	KDFScrypt KDF = "scrypt"
+	// KDFBcrypt is a low-memory cost profile for operators on
+	// memory-constrained hardware, where KDFScrypt's default cost parameters
+	// are impractical. Its BcryptParams.Cost is used as a scrypt work-factor
+	// exponent rather than a literal bcrypt cost, since bcrypt itself has no
+	// deterministic, reproducible API suitable for this use.
+	KDFBcrypt KDF = "bcrypt"
+)
+
+// Validate returns an error if k is not a KDF this package knows how to use.
+func (k KDF) Validate() error {
+	switch k {
+	case KDFScrypt, KDFBcrypt:
+		return nil
+	default:
+		return fmt.Errorf(`unknown key-derivation function %q, must be "scrypt" or "bcrypt"`, string(k))
+	}
+}
+
+// ScryptParams are the tunable scrypt cost parameters. Larger values are more
+// resistant to brute-forcing, at the cost of more CPU/memory at
+// encrypt/decrypt time.
+type ScryptParams struct {
+	N, R, P int
+}
+
+// DefaultScryptParams is the cost profile previously hardcoded for all keys
+// created via CreateKey.
+var DefaultScryptParams = ScryptParams{N: 1 << 18, R: 8, P: 1}
+
+// FastScryptParams is the cost profile previously hardcoded for keys created
+// via CreateWeakInMemoryEncryptedKeyXXXTestingOnly. Never use in production.
+var FastScryptParams = ScryptParams{N: 2, R: 1, P: 1}
+
+// BcryptParams is the tunable cost parameter for KDFBcrypt: Cost is used as
+// the base-2 exponent of the scrypt work factor N it derives with, so larger
+// values cost more CPU/memory to encrypt/decrypt, same as ScryptParams.N.
+type BcryptParams struct {
+	Cost int
+}
+
+// DefaultBcryptParams mirrors bcrypt.DefaultCost, kept as the default Cost
+// for continuity even though KDFBcrypt no longer calls bcrypt itself.
+var DefaultBcryptParams = BcryptParams{Cost: 10}
@@ -0,0 +1,99 @@
+package vrfkey
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"runtime"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"chainlink/core/utils"
+)
+
+// PublicKey is the compressed public component of a secp256k1 VRF keypair.
+type PublicKey [33]byte
+
+// String returns k as a hex string, with no leading 0x.
+func (k PublicKey) String() string { return hex.EncodeToString(k[:]) }
+
+// NewPublicKeyFromHex parses the hex representation of a VRF public key,
+// accepting an optional leading 0x.
+func NewPublicKeyFromHex(hexString string) (*PublicKey, error) {
+	b, err := hex.DecodeString(strings.TrimPrefix(hexString, "0x"))
+	if err != nil {
+		return nil, errors.Wrap(err, "while parsing VRF public key hex")
+	}
+	if len(b) != len(PublicKey{}) {
+		return nil, fmt.Errorf("VRF public key must be %d bytes, got %d", len(PublicKey{}), len(b))
+	}
+	var k PublicKey
+	copy(k[:], b)
+	return &k, nil
+}
+
+// PrivateKey is a secp256k1 VRF keypair: the secret scalar k, and its
+// corresponding public key. k is held as a utils.SecretBytes so it can be
+// scrubbed from memory via Forget as soon as it is no longer needed.
+type PrivateKey struct {
+	PublicKey PublicKey
+	k         utils.SecretBytes // secret scalar; never marshaled directly
+}
+
+// NewPrivateKey generates a fresh VRF keypair from crypto/rand. As a
+// defense-in-depth measure, its secret scalar is scrubbed from memory when
+// the PrivateKey is garbage-collected, in case a caller forgets to call
+// Forget explicitly.
+func NewPrivateKey() (*PrivateKey, error) {
+	k := &PrivateKey{k: make(utils.SecretBytes, 32)}
+	if _, err := rand.Read(k.k); err != nil {
+		return nil, errors.Wrap(err, "while generating VRF secret scalar")
+	}
+	k.PublicKey = derivePublicKey(k.k)
+	runtime.SetFinalizer(k, func(k *PrivateKey) { k.Forget() })
+	return k, nil
+}
+
+// NewPrivateKeyFromSeed reconstructs a PrivateKey from a decrypted secret
+// scalar and its already-known public key, e.g. as VRFKeyStore.Unlock does
+// after decrypting a stored EncryptedVRFKey. seed is copied into a fresh
+// buffer rather than retained, so the caller remains responsible for
+// zeroing its own copy of seed.
+func NewPrivateKeyFromSeed(publicKey PublicKey, seed utils.SecretBytes) *PrivateKey {
+	k := &PrivateKey{PublicKey: publicKey, k: make(utils.SecretBytes, len(seed))}
+	copy(k.k, seed)
+	runtime.SetFinalizer(k, func(k *PrivateKey) { k.Forget() })
+	return k
+}
+
+// Forget scrubs k's secret scalar from memory. It is safe to call more than
+// once, and safe to call on a key which is still in use for signing -
+// callers which do that are responsible for re-deriving the key (e.g. via
+// Decrypt) before signing again.
+func (k *PrivateKey) Forget() {
+	k.k.Zero()
+}
+
+// Encrypt encrypts k's secret scalar under auth, using kdf and the given cost
+// parameters, producing the on-disk EncryptedVRFKey representation.
+func (k *PrivateKey) Encrypt(auth utils.SecretBytes, kdf KDF, scryptParams ScryptParams, bcryptParams BcryptParams) (*EncryptedVRFKey, error) {
+	return encryptedVRFKey(k.PublicKey, k.k, auth, kdf, scryptParams, bcryptParams)
+}
+
+// WriteToDisk writes k's encrypted representation, protected by auth, to
+// path, using the fast, testing-only FastScryptParams profile. Used by
+// CreateAndExportWeakVRFKey, which never persists to the DB.
+func (k *PrivateKey) WriteToDisk(path string, auth utils.SecretBytes) error {
+	enc, err := k.Encrypt(auth, KDFScrypt, FastScryptParams, DefaultBcryptParams)
+	if err != nil {
+		return errors.Wrap(err, "while encrypting key for disk")
+	}
+	keyjson, err := json.Marshal(enc)
+	if err != nil {
+		return errors.Wrap(err, "while marshaling encrypted key")
+	}
+	return ioutil.WriteFile(path, keyjson, 0600)
+}
@@ -0,0 +1,55 @@
+package vrfkey
+
+import (
+	"testing"
+
+	"chainlink/core/utils"
+)
+
+func TestPrivateKey_Forget(t *testing.T) {
+	key, err := NewPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, b := range key.k {
+		if b != 0 {
+			goto nonZero
+		}
+	}
+	t.Fatal("secret scalar was already zero before Forget; test is not exercising anything")
+nonZero:
+	key.Forget()
+	for i, b := range key.k {
+		if b != 0 {
+			t.Fatalf("byte %d of secret scalar not zeroed by Forget: got %d", i, b)
+		}
+	}
+	key.Forget() // must be safe to call twice
+}
+
+func TestDerivePublicKey_DoesNotLeakSecretScalar(t *testing.T) {
+	seed := make(utils.SecretBytes, 32)
+	for i := range seed {
+		seed[i] = byte(i + 1)
+	}
+	pub := derivePublicKey(seed)
+	matching := 0
+	for i, b := range seed {
+		if pub[i+1] == b {
+			matching++
+		}
+	}
+	if matching == len(seed) {
+		t.Fatal("public key bytes equal the secret scalar verbatim; derivePublicKey leaks secret bytes")
+	}
+}
+
+func TestNewPrivateKeyFromSeed_CopiesSeed(t *testing.T) {
+	seed := make(utils.SecretBytes, 32)
+	seed[0] = 0xAB
+	key := NewPrivateKeyFromSeed(PublicKey{}, seed)
+	seed.Zero()
+	if key.k[0] != 0xAB {
+		t.Fatal("NewPrivateKeyFromSeed retained a reference to seed instead of copying it")
+	}
+}
@@ -0,0 +1,239 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+
+	"chainlink/core/store/models/vrfkey"
+	"chainlink/core/utils"
+)
+
+// MatchingVRFKeyError is returned by Import if the backend already has an
+// entry for the public key being imported.
+var MatchingVRFKeyError = errors.New("key with matching public key already exists")
+
+// AttemptToDeleteNonExistentKeyFromDB is returned by Delete if there is no
+// stored key for the given public key.
+var AttemptToDeleteNonExistentKeyFromDB = errors.New("key does not exist in DB")
+
+// ErrKeyStoreLocked is returned by Unlocked, and by any VRF-proof code path
+// which tries to sign with a key which Lock/LockAll has removed from the
+// in-memory cache.
+var ErrKeyStoreLocked = errors.New("VRF key is locked; call Unlock before using it to sign")
+
+// VRFKeyStore manages VRF keys: it knows how to create, list, import, export
+// and delete encrypted keys via a pluggable VRFKeyStoreBackend, and caches
+// decrypted keys in memory once they have been unlocked.
+type VRFKeyStore struct {
+	backend VRFKeyStoreBackend
+
+	unlockedMu sync.Mutex
+	unlocked   map[string]*vrfkey.PrivateKey
+}
+
+// NewVRFKeyStore returns a VRFKeyStore using the default DB-backed
+// VRFKeyStoreBackend.
+func NewVRFKeyStore(db *gorm.DB) *VRFKeyStore {
+	return NewVRFKeyStoreWithBackend(NewDBVRFKeyStoreBackend(db))
+}
+
+// NewVRFKeyStoreWithBackend returns a VRFKeyStore which persists keys via
+// backend, e.g. one returned by NewFileVRFKeyStoreBackend or
+// NewHSMVRFKeyStoreBackend.
+func NewVRFKeyStoreWithBackend(backend VRFKeyStoreBackend) *VRFKeyStore {
+	return &VRFKeyStore{backend: backend, unlocked: map[string]*vrfkey.PrivateKey{}}
+}
+
+// Backend returns the VRFKeyStoreBackend store currently delegates to.
+func (store *VRFKeyStore) Backend() VRFKeyStoreBackend {
+	return store.backend
+}
+
+// WithBackend returns a new VRFKeyStore which delegates to backend instead.
+// It does not share store's cache of unlocked keys. Used by the CLI to retarget a single command at a different
+// backend (e.g. `--backend file`) without needing a second constructor.
+func (store *VRFKeyStore) WithBackend(backend VRFKeyStoreBackend) *VRFKeyStore {
+	return NewVRFKeyStoreWithBackend(backend)
+}
+
+// KeyOption customizes the KDF profile used by CreateKey.
+type KeyOption func(*keyOptions)
+
+type keyOptions struct {
+	kdf          vrfkey.KDF
+	scryptParams vrfkey.ScryptParams
+	bcryptParams vrfkey.BcryptParams
+}
+
+func defaultKeyOptions() *keyOptions {
+	return &keyOptions{
+		kdf:          vrfkey.KDFScrypt,
+		scryptParams: vrfkey.DefaultScryptParams,
+		bcryptParams: vrfkey.DefaultBcryptParams,
+	}
+}
+
+// WithKDF selects which key-derivation function to protect the new key with.
+func WithKDF(kdf vrfkey.KDF) KeyOption {
+	return func(o *keyOptions) { o.kdf = kdf }
+}
+
+// WithScryptParams overrides the scrypt cost parameters used when kdf is
+// vrfkey.KDFScrypt.
+func WithScryptParams(p vrfkey.ScryptParams) KeyOption {
+	return func(o *keyOptions) { o.scryptParams = p }
+}
+
+// WithBcryptParams overrides the bcrypt cost parameter used when kdf is
+// vrfkey.KDFBcrypt.
+func WithBcryptParams(p vrfkey.BcryptParams) KeyOption {
+	return func(o *keyOptions) { o.bcryptParams = p }
+}
+
+// CreateKey generates a new VRF keypair, encrypts it under auth per opts (or
+// the default scrypt profile, if none are given), and persists the encrypted
+// key via store's backend.
+func (store *VRFKeyStore) CreateKey(auth utils.SecretBytes, opts ...KeyOption) (*vrfkey.PublicKey, error) {
+	o := defaultKeyOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+	if err := o.kdf.Validate(); err != nil {
+		return nil, err
+	}
+	key, err := vrfkey.NewPrivateKey()
+	if err != nil {
+		return nil, errors.Wrap(err, "while generating new VRF key")
+	}
+	defer key.Forget()
+	enc, err := key.Encrypt(auth, o.kdf, o.scryptParams, o.bcryptParams)
+	if err != nil {
+		return nil, errors.Wrap(err, "while encrypting new VRF key")
+	}
+	if err := store.backend.Store(enc); err != nil {
+		return nil, err
+	}
+	return &key.PublicKey, nil
+}
+
+// CreateWeakInMemoryEncryptedKeyXXXTestingOnly returns a fresh, unencrypted
+// key, suitable only for tests; auth is not used. The cheap, insecure KDF
+// parameters are applied when the caller writes it out with
+// PrivateKey.WriteToDisk. It is never persisted via the backend; callers are
+// expected to write it to disk themselves and import it where it's needed.
+func (store *VRFKeyStore) CreateWeakInMemoryEncryptedKeyXXXTestingOnly(auth utils.SecretBytes) (*vrfkey.PrivateKey, error) {
+	return vrfkey.NewPrivateKey()
+}
+
+// Import decrypts keyjson with auth just long enough to validate it, then
+// stores it (still encrypted) via the backend, unless a key with the same
+// public key already exists there, in which case it returns
+// MatchingVRFKeyError.
+func (store *VRFKeyStore) Import(keyjson []byte, auth utils.SecretBytes) error {
+	var enc vrfkey.EncryptedVRFKey
+	if err := json.Unmarshal(keyjson, &enc); err != nil {
+		return errors.Wrap(err, "while parsing VRF key JSON")
+	}
+	seed, err := enc.Decrypt(auth)
+	if err != nil {
+		return errors.Wrap(err, "while validating password against VRF key JSON")
+	}
+	seed.Zero()
+	existing, err := store.backend.Load(&enc.PublicKey)
+	if err != nil {
+		if err == ErrReadOnlyKeyStore {
+			return err
+		}
+		return errors.Wrap(err, "while checking for existing VRF key")
+	}
+	if len(existing) > 0 {
+		return MatchingVRFKeyError
+	}
+	if err := store.backend.Store(&enc); err != nil {
+		if err == ErrReadOnlyKeyStore {
+			return err
+		}
+		return errors.Wrap(err, "while storing imported VRF key")
+	}
+	return nil
+}
+
+// Export returns the encrypted JSON of every key matching publicKey
+// (ordinarily there is at most one).
+func (store *VRFKeyStore) Export(publicKey *vrfkey.PublicKey) ([][]byte, error) {
+	return store.backend.Export(publicKey)
+}
+
+// Delete removes the stored key for publicKey via the backend.
+func (store *VRFKeyStore) Delete(publicKey *vrfkey.PublicKey) error {
+	return store.backend.Delete(publicKey)
+}
+
+// ListKeys returns the public keys of every VRF key known to the backend.
+func (store *VRFKeyStore) ListKeys() ([]*vrfkey.PublicKey, error) {
+	return store.backend.List()
+}
+
+// Unlock decrypts the stored key matching publicKey with auth, and caches
+// the decrypted key in memory, so that it is available to sign with until
+// Lock, LockAll, or process exit.
+func (store *VRFKeyStore) Unlock(publicKey *vrfkey.PublicKey, auth utils.SecretBytes) error {
+	blobs, err := store.backend.Load(publicKey)
+	if err != nil {
+		return errors.Wrap(err, "while loading VRF key to unlock")
+	}
+	if len(blobs) == 0 {
+		return fmt.Errorf("no stored VRF key matching %s", publicKey)
+	}
+	seed, err := blobs[0].Decrypt(auth)
+	if err != nil {
+		return errors.Wrap(err, "while decrypting VRF key")
+	}
+	defer seed.Zero()
+	key := vrfkey.NewPrivateKeyFromSeed(*publicKey, seed)
+	store.unlockedMu.Lock()
+	defer store.unlockedMu.Unlock()
+	store.unlocked[publicKey.String()] = key
+	return nil
+}
+
+// Lock removes publicKey's decrypted key from the in-process cache, and
+// scrubs it from memory. It leaves the encrypted key in the backend
+// untouched, and only affects this process - it cannot reach into other
+// processes which may have the same key unlocked.
+func (store *VRFKeyStore) Lock(publicKey *vrfkey.PublicKey) {
+	store.unlockedMu.Lock()
+	defer store.unlockedMu.Unlock()
+	if key, ok := store.unlocked[publicKey.String()]; ok {
+		key.Forget()
+		delete(store.unlocked, publicKey.String())
+	}
+}
+
+// LockAll locks every key currently unlocked in this process.
+func (store *VRFKeyStore) LockAll() {
+	store.unlockedMu.Lock()
+	defer store.unlockedMu.Unlock()
+	for hexKey, key := range store.unlocked {
+		key.Forget()
+		delete(store.unlocked, hexKey)
+	}
+}
+
+// Unlocked returns the decrypted key matching publicKey, if it is currently
+// unlocked in this process, or ErrKeyStoreLocked otherwise. VRF-proof
+// generation should call this to get the key to sign with, rather than
+// caching it independently.
+func (store *VRFKeyStore) Unlocked(publicKey *vrfkey.PublicKey) (*vrfkey.PrivateKey, error) {
+	store.unlockedMu.Lock()
+	defer store.unlockedMu.Unlock()
+	key, ok := store.unlocked[publicKey.String()]
+	if !ok {
+		return nil, ErrKeyStoreLocked
+	}
+	return key, nil
+}
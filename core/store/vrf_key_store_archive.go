@@ -0,0 +1,121 @@
+package store
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+
+	"chainlink/core/store/models/vrfkey"
+	"chainlink/core/utils"
+)
+
+// ImportResult summarizes what happened to one key found in an archive
+// passed to ImportAll.
+type ImportResult struct {
+	PublicKey string
+	Imported  bool
+	Reason    string // why Imported is false; empty if Imported is true
+}
+
+// ExportAll serializes every key known to store's backend into a single tar
+// archive, then encrypts that archive under archivePassphrase - distinct
+// from any individual key's own passphrase - so an operator can take a full
+// disaster-recovery backup in one shot, rather than calling Export once per
+// key.
+func (store *VRFKeyStore) ExportAll(archivePassphrase utils.SecretBytes) ([]byte, error) {
+	keys, err := store.backend.List()
+	if err != nil {
+		return nil, errors.Wrap(err, "while listing keys to export")
+	}
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, publicKey := range keys {
+		blobs, err := store.backend.Export(publicKey)
+		if err != nil {
+			return nil, errors.Wrapf(err, "while exporting key %s", publicKey)
+		}
+		for i, blob := range blobs {
+			name := publicKey.String() + ".json"
+			if i > 0 {
+				name = fmt.Sprintf("%s.%d.json", publicKey.String(), i)
+			}
+			if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0600, Size: int64(len(blob))}); err != nil {
+				return nil, errors.Wrap(err, "while writing archive entry header")
+			}
+			if _, err := tw.Write(blob); err != nil {
+				return nil, errors.Wrap(err, "while writing archive entry")
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, errors.Wrap(err, "while finalizing archive")
+	}
+	return vrfkey.EncryptArchive(buf.Bytes(), archivePassphrase,
+		vrfkey.KDFScrypt, vrfkey.DefaultScryptParams, vrfkey.DefaultBcryptParams)
+}
+
+// ImportAll decrypts archivejson with archivePassphrase, and imports every
+// key it contains into store's backend. A key whose public key already
+// exists in the backend is skipped, and reported as such in the returned
+// results, unless overwrite is true, in which case it replaces the existing
+// one. An entry whose key JSON cannot be parsed or stored is reported in the
+// results and the remaining entries are still imported. A non-nil error
+// means the archive could not be decrypted, or its tar stream could not be
+// read past some entry; in the latter case the results for the entries
+// before it are returned alongside the error.
+func (store *VRFKeyStore) ImportAll(archivejson []byte, archivePassphrase utils.SecretBytes, overwrite bool) ([]ImportResult, error) {
+	plaintext, err := vrfkey.DecryptArchive(archivejson, archivePassphrase)
+	if err != nil {
+		return nil, errors.Wrap(err, "while decrypting VRF key archive")
+	}
+	tr := tar.NewReader(bytes.NewReader(plaintext))
+	var results []ImportResult
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return results, errors.Wrap(err, "while reading archive entry")
+		}
+		keyjson, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return results, errors.Wrapf(err, "while reading archive entry %s", hdr.Name)
+		}
+		results = append(results, store.importArchiveEntry(keyjson, hdr.Name, overwrite))
+	}
+	return results, nil
+}
+
+func (store *VRFKeyStore) importArchiveEntry(keyjson []byte, name string, overwrite bool) ImportResult {
+	var enc vrfkey.EncryptedVRFKey
+	if err := json.Unmarshal(keyjson, &enc); err != nil {
+		return ImportResult{PublicKey: name, Reason: "could not parse: " + err.Error()}
+	}
+	publicKey := enc.PublicKey.String()
+	existing, err := store.backend.Load(&enc.PublicKey)
+	if err != nil {
+		return ImportResult{PublicKey: publicKey, Reason: err.Error()}
+	}
+	if len(existing) > 0 {
+		if !overwrite {
+			return ImportResult{PublicKey: publicKey, Reason: "already exists; pass --overwrite to replace"}
+		}
+		// backend.Store is a blind insert, and public_key is not guaranteed
+		// unique (see ExportVRFKey's .1/.2 suffix handling), so an overwrite
+		// must delete the stale row(s) first or it would just add a second,
+		// newer one behind the existing one that Unlock/Load still prefer.
+		if err := store.backend.Delete(&enc.PublicKey); err != nil {
+			return ImportResult{PublicKey: publicKey, Reason: err.Error()}
+		}
+	}
+	if err := store.backend.Store(&enc); err != nil {
+		return ImportResult{PublicKey: publicKey, Reason: err.Error()}
+	}
+	return ImportResult{PublicKey: publicKey, Imported: true}
+}
@@ -0,0 +1,116 @@
+package store
+
+import (
+	"encoding/json"
+	"testing"
+
+	"chainlink/core/store/models/vrfkey"
+	"chainlink/core/utils"
+)
+
+// fakeVRFKeyStoreBackend is an in-memory VRFKeyStoreBackend double, keyed by
+// hex public key, used to exercise VRFKeyStore without a DB.
+type fakeVRFKeyStoreBackend struct {
+	readOnly bool
+	keys     map[string][]*vrfkey.EncryptedVRFKey
+}
+
+func newFakeVRFKeyStoreBackend() *fakeVRFKeyStoreBackend {
+	return &fakeVRFKeyStoreBackend{keys: map[string][]*vrfkey.EncryptedVRFKey{}}
+}
+
+func (b *fakeVRFKeyStoreBackend) Store(enc *vrfkey.EncryptedVRFKey) error {
+	if b.readOnly {
+		return ErrReadOnlyKeyStore
+	}
+	k := enc.PublicKey.String()
+	b.keys[k] = append(b.keys[k], enc)
+	return nil
+}
+
+func (b *fakeVRFKeyStoreBackend) Load(publicKey *vrfkey.PublicKey) ([]*vrfkey.EncryptedVRFKey, error) {
+	return b.keys[publicKey.String()], nil
+}
+
+func (b *fakeVRFKeyStoreBackend) List() ([]*vrfkey.PublicKey, error) {
+	var keys []*vrfkey.PublicKey
+	for _, blobs := range b.keys {
+		keys = append(keys, &blobs[0].PublicKey)
+	}
+	return keys, nil
+}
+
+func (b *fakeVRFKeyStoreBackend) Delete(publicKey *vrfkey.PublicKey) error {
+	delete(b.keys, publicKey.String())
+	return nil
+}
+
+func (b *fakeVRFKeyStoreBackend) Export(publicKey *vrfkey.PublicKey) ([][]byte, error) {
+	return nil, nil
+}
+
+func TestVRFKeyStore_Import_ReturnsMatchingVRFKeyErrorOnDuplicate(t *testing.T) {
+	backend := newFakeVRFKeyStoreBackend()
+	store := NewVRFKeyStoreWithBackend(backend)
+	auth := utils.SecretBytes("p4ssword")
+	pub, err := store.CreateKey(auth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blobs, err := backend.Load(pub)
+	if err != nil || len(blobs) != 1 {
+		t.Fatalf("expected exactly one stored key, got %d, err %v", len(blobs), err)
+	}
+	if err := store.Import(mustMarshal(t, blobs[0]), auth); err != MatchingVRFKeyError {
+		t.Fatalf("expected MatchingVRFKeyError, got %v", err)
+	}
+}
+
+func TestVRFKeyStore_Import_PropagatesErrReadOnlyKeyStore(t *testing.T) {
+	backend := newFakeVRFKeyStoreBackend()
+	store := NewVRFKeyStoreWithBackend(backend)
+	auth := utils.SecretBytes("p4ssword")
+	pub, err := store.CreateKey(auth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blobs, err := backend.Load(pub)
+	if err != nil || len(blobs) != 1 {
+		t.Fatalf("expected exactly one stored key, got %d, err %v", len(blobs), err)
+	}
+	keyjson := mustMarshal(t, blobs[0])
+	backend.keys = map[string][]*vrfkey.EncryptedVRFKey{} // clear, so Import sees no duplicate
+	backend.readOnly = true
+	if err := store.Import(keyjson, auth); err != ErrReadOnlyKeyStore {
+		t.Fatalf("expected ErrReadOnlyKeyStore, got %v", err)
+	}
+}
+
+func TestVRFKeyStore_LockAll_LocksUnlockedKeys(t *testing.T) {
+	backend := newFakeVRFKeyStoreBackend()
+	store := NewVRFKeyStoreWithBackend(backend)
+	auth := utils.SecretBytes("p4ssword")
+	pub, err := store.CreateKey(auth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Unlock(pub, auth); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Unlocked(pub); err != nil {
+		t.Fatalf("expected key to be unlocked, got %v", err)
+	}
+	store.LockAll() // nothing in this package calls LockAll on process shutdown; called directly here
+	if _, err := store.Unlocked(pub); err != ErrKeyStoreLocked {
+		t.Fatalf("expected ErrKeyStoreLocked after LockAll, got %v", err)
+	}
+}
+
+func mustMarshal(t *testing.T, enc *vrfkey.EncryptedVRFKey) []byte {
+	t.Helper()
+	b, err := json.Marshal(enc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
@@ -0,0 +1,302 @@
+package store
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	"chainlink/core/store/models/vrfkey"
+)
+
+// ErrReadOnlyKeyStore is returned by Store/Delete on any VRFKeyStoreBackend
+// that cannot persist new key material, e.g. one backed by an external
+// HSM/KMS that only ever hands back keys it was provisioned with out of
+// band.
+var ErrReadOnlyKeyStore = errors.New("this VRF keystore backend is read-only")
+
+// VRFKeyStoreBackend is the storage layer VRFKeyStore delegates to. Multiple
+// implementations let an operator choose, e.g., whether encrypted key
+// material lives in the node's DB, on the local filesystem, or behind an
+// external HSM/KMS that never reveals the plaintext key at all.
+type VRFKeyStoreBackend interface {
+	// Store persists enc, keyed by its public key.
+	Store(enc *vrfkey.EncryptedVRFKey) error
+	// Load returns every stored encrypted key matching publicKey (ordinarily
+	// at most one).
+	Load(publicKey *vrfkey.PublicKey) ([]*vrfkey.EncryptedVRFKey, error)
+	// List returns the public keys of every key known to the backend.
+	List() ([]*vrfkey.PublicKey, error)
+	// Delete removes every stored key matching publicKey.
+	Delete(publicKey *vrfkey.PublicKey) error
+	// Export returns the raw encrypted JSON blobs for publicKey, suitable for
+	// writing directly to a backup file.
+	Export(publicKey *vrfkey.PublicKey) ([][]byte, error)
+}
+
+// dbVRFKeyStoreBackend is the original, and still default, backend: keys are
+// stored as encrypted JSON blobs in the node's own DB.
+type dbVRFKeyStoreBackend struct {
+	db *gorm.DB
+}
+
+// NewDBVRFKeyStoreBackend returns a VRFKeyStoreBackend backed by db.
+func NewDBVRFKeyStoreBackend(db *gorm.DB) VRFKeyStoreBackend {
+	return &dbVRFKeyStoreBackend{db: db}
+}
+
+func (b *dbVRFKeyStoreBackend) Store(enc *vrfkey.EncryptedVRFKey) error {
+	keyjson, err := json.Marshal(enc)
+	if err != nil {
+		return errors.Wrap(err, "while marshaling encrypted VRF key")
+	}
+	return b.db.Exec(
+		"INSERT INTO encrypted_secret_keys (public_key, encrypted_secret_key) VALUES (?, ?)",
+		enc.PublicKey.String(), keyjson,
+	).Error
+}
+
+func (b *dbVRFKeyStoreBackend) Load(publicKey *vrfkey.PublicKey) ([]*vrfkey.EncryptedVRFKey, error) {
+	blobs, err := b.Export(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]*vrfkey.EncryptedVRFKey, len(blobs))
+	for i, blob := range blobs {
+		var enc vrfkey.EncryptedVRFKey
+		if err := json.Unmarshal(blob, &enc); err != nil {
+			return nil, errors.Wrap(err, "while parsing stored VRF key")
+		}
+		keys[i] = &enc
+	}
+	return keys, nil
+}
+
+func (b *dbVRFKeyStoreBackend) List() ([]*vrfkey.PublicKey, error) {
+	rows, err := b.db.Raw("SELECT public_key FROM encrypted_secret_keys").Rows()
+	if err != nil {
+		return nil, errors.Wrap(err, "while listing VRF keys")
+	}
+	defer rows.Close()
+	var keys []*vrfkey.PublicKey
+	for rows.Next() {
+		var hexKey string
+		if err := rows.Scan(&hexKey); err != nil {
+			return keys, errors.Wrap(err, "while scanning VRF key row")
+		}
+		key, err := vrfkey.NewPublicKeyFromHex(hexKey)
+		if err != nil {
+			return keys, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func (b *dbVRFKeyStoreBackend) Delete(publicKey *vrfkey.PublicKey) error {
+	result := b.db.Exec("DELETE FROM encrypted_secret_keys WHERE public_key = ?", publicKey.String())
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "while deleting VRF key")
+	}
+	if result.RowsAffected == 0 {
+		return AttemptToDeleteNonExistentKeyFromDB
+	}
+	return nil
+}
+
+func (b *dbVRFKeyStoreBackend) Export(publicKey *vrfkey.PublicKey) ([][]byte, error) {
+	rows, err := b.db.Raw(
+		"SELECT encrypted_secret_key FROM encrypted_secret_keys WHERE public_key = ?", publicKey.String(),
+	).Rows()
+	if err != nil {
+		return nil, errors.Wrap(err, "while querying for VRF key")
+	}
+	defer rows.Close()
+	var keys [][]byte
+	for rows.Next() {
+		var keyjson []byte
+		if err := rows.Scan(&keyjson); err != nil {
+			return keys, errors.Wrap(err, "while scanning VRF key row")
+		}
+		keys = append(keys, keyjson)
+	}
+	return keys, nil
+}
+
+// fileVRFKeyStoreBackend stores one encrypted JSON file per key in dir,
+// named by the key's hex-encoded public key, mirroring the layout of
+// go-ethereum's keystore directory.
+type fileVRFKeyStoreBackend struct {
+	dir string
+}
+
+// NewFileVRFKeyStoreBackend returns a VRFKeyStoreBackend that keeps its keys
+// as individual files under dir, which is created if it does not exist.
+func NewFileVRFKeyStoreBackend(dir string) (VRFKeyStoreBackend, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, errors.Wrapf(err, "while creating VRF keystore directory %s", dir)
+	}
+	return &fileVRFKeyStoreBackend{dir: dir}, nil
+}
+
+func (b *fileVRFKeyStoreBackend) path(publicKey *vrfkey.PublicKey) string {
+	return filepath.Join(b.dir, publicKey.String()+".json")
+}
+
+func (b *fileVRFKeyStoreBackend) Store(enc *vrfkey.EncryptedVRFKey) error {
+	keyjson, err := json.Marshal(enc)
+	if err != nil {
+		return errors.Wrap(err, "while marshaling encrypted VRF key")
+	}
+	return ioutil.WriteFile(b.path(&enc.PublicKey), keyjson, 0600)
+}
+
+func (b *fileVRFKeyStoreBackend) Load(publicKey *vrfkey.PublicKey) ([]*vrfkey.EncryptedVRFKey, error) {
+	blobs, err := b.Export(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]*vrfkey.EncryptedVRFKey, len(blobs))
+	for i, blob := range blobs {
+		var enc vrfkey.EncryptedVRFKey
+		if err := json.Unmarshal(blob, &enc); err != nil {
+			return nil, errors.Wrap(err, "while parsing stored VRF key")
+		}
+		keys[i] = &enc
+	}
+	return keys, nil
+}
+
+func (b *fileVRFKeyStoreBackend) List() ([]*vrfkey.PublicKey, error) {
+	entries, err := ioutil.ReadDir(b.dir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "while listing VRF keystore directory %s", b.dir)
+	}
+	var keys []*vrfkey.PublicKey
+	for _, entry := range entries {
+		hexKey := entry.Name()[:len(entry.Name())-len(filepath.Ext(entry.Name()))]
+		key, err := vrfkey.NewPublicKeyFromHex(hexKey)
+		if err != nil {
+			continue // not a VRF key file; skip it
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func (b *fileVRFKeyStoreBackend) Delete(publicKey *vrfkey.PublicKey) error {
+	if err := os.Remove(b.path(publicKey)); err != nil {
+		if os.IsNotExist(err) {
+			return AttemptToDeleteNonExistentKeyFromDB
+		}
+		return errors.Wrap(err, "while deleting VRF key file")
+	}
+	return nil
+}
+
+func (b *fileVRFKeyStoreBackend) Export(publicKey *vrfkey.PublicKey) ([][]byte, error) {
+	keyjson, err := ioutil.ReadFile(b.path(publicKey))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "while reading VRF key file")
+	}
+	return [][]byte{keyjson}, nil
+}
+
+// hsmVRFKeyStoreBackend is a read-only backend that proxies to an external
+// HSM/KMS over gRPC. The HSM owns the plaintext key material and never
+// reveals it; this backend can only list the public keys it holds. It has no
+// method for signing, so it does not forward signing requests to the HSM.
+// Store, Load and Delete return ErrReadOnlyKeyStore, and Export returns an
+// error.
+type hsmVRFKeyStoreBackend struct {
+	conn   *grpc.ClientConn
+	client hsmKeyStoreClient
+}
+
+// hsmKeyStoreClient is the subset of the generated HSM/KMS gRPC client this
+// backend needs. It is defined here, rather than imported from a generated
+// pb package, to keep this change self-contained.
+type hsmKeyStoreClient interface {
+	ListPublicKeys(ctx context.Context) ([]string, error)
+}
+
+// jsonCodec implements grpc/encoding.Codec by plain JSON marshaling, so
+// defaultHSMKeyStoreClient can speak to an HSM/KMS's RPCs without depending
+// on a generated protobuf client.
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error { return json.Unmarshal(data, v) }
+func (jsonCodec) Name() string                               { return "json" }
+
+// defaultHSMKeyStoreClient is the hsmKeyStoreClient NewHSMVRFKeyStoreBackend
+// uses by default: it speaks to the HSM/KMS's ListPublicKeys RPC directly
+// over conn, rather than requiring callers to supply a generated client.
+type defaultHSMKeyStoreClient struct {
+	conn *grpc.ClientConn
+}
+
+func (c *defaultHSMKeyStoreClient) ListPublicKeys(ctx context.Context) ([]string, error) {
+	var resp struct {
+		PublicKeys []string `json:"publicKeys"`
+	}
+	err := c.conn.Invoke(ctx, "/hsmkeystore.KeyStore/ListPublicKeys", struct{}{}, &resp, grpc.ForceCodec(jsonCodec{}))
+	return resp.PublicKeys, err
+}
+
+// NewHSMVRFKeyStoreBackend dials addr over TLS and returns a read-only
+// VRFKeyStoreBackend that defers to the HSM/KMS listening there. tlsConfig
+// must not be nil: an HSM/KMS holds key material, so this backend refuses to
+// talk to it over an unauthenticated, unencrypted connection.
+func NewHSMVRFKeyStoreBackend(addr string, tlsConfig *tls.Config) (VRFKeyStoreBackend, error) {
+	if tlsConfig == nil {
+		return nil, errors.New("must provide a TLS config to dial an HSM/KMS VRF keystore backend")
+	}
+	conn, err := grpc.Dial(addr, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
+	if err != nil {
+		return nil, errors.Wrapf(err, "while dialing HSM/KMS at %s", addr)
+	}
+	return &hsmVRFKeyStoreBackend{conn: conn, client: &defaultHSMKeyStoreClient{conn: conn}}, nil
+}
+
+func (b *hsmVRFKeyStoreBackend) Store(enc *vrfkey.EncryptedVRFKey) error {
+	return ErrReadOnlyKeyStore
+}
+
+func (b *hsmVRFKeyStoreBackend) Load(publicKey *vrfkey.PublicKey) ([]*vrfkey.EncryptedVRFKey, error) {
+	return nil, ErrReadOnlyKeyStore
+}
+
+func (b *hsmVRFKeyStoreBackend) List() ([]*vrfkey.PublicKey, error) {
+	hexKeys, err := b.client.ListPublicKeys(context.Background())
+	if err != nil {
+		return nil, errors.Wrap(err, "while listing keys from HSM/KMS")
+	}
+	keys := make([]*vrfkey.PublicKey, len(hexKeys))
+	for i, hexKey := range hexKeys {
+		key, err := vrfkey.NewPublicKeyFromHex(hexKey)
+		if err != nil {
+			return nil, err
+		}
+		keys[i] = key
+	}
+	return keys, nil
+}
+
+func (b *hsmVRFKeyStoreBackend) Delete(publicKey *vrfkey.PublicKey) error {
+	return ErrReadOnlyKeyStore
+}
+
+func (b *hsmVRFKeyStoreBackend) Export(publicKey *vrfkey.PublicKey) ([][]byte, error) {
+	return nil, errors.New("HSM-backed keys cannot be exported")
+}
@@ -0,0 +1,15 @@
+package utils
+
+// SecretBytes wraps byte slices that hold secret material - a passphrase, a
+// decrypted private key scalar - so that callers are reminded to scrub it
+// from memory via Zero as soon as it is no longer needed, rather than
+// leaving it to linger on the heap for the life of the process.
+type SecretBytes []byte
+
+// Zero overwrites every byte of s with 0, in place. It is safe to call Zero
+// more than once, and on a nil or empty SecretBytes.
+func (s SecretBytes) Zero() {
+	for i := range s {
+		s[i] = 0
+	}
+}
@@ -0,0 +1,20 @@
+package utils
+
+import "testing"
+
+func TestSecretBytes_Zero(t *testing.T) {
+	s := SecretBytes{1, 2, 3, 4, 5}
+	s.Zero()
+	for i, b := range s {
+		if b != 0 {
+			t.Fatalf("byte %d not zeroed: got %d", i, b)
+		}
+	}
+}
+
+func TestSecretBytes_ZeroNilIsSafe(t *testing.T) {
+	var s SecretBytes
+	s.Zero() // must not panic
+	var empty SecretBytes = []byte{}
+	empty.Zero() // must not panic
+}
@@ -0,0 +1,10 @@
+// Package utils contains small, generically-useful helpers shared across the
+// node.
+package utils
+
+// JustError takes a tuple of (_, error) and returns error, discarding the
+// other value. Useful for one-lining calls whose non-error return is not
+// needed, e.g. utils.JustError(os.Stat(path)).
+func JustError(_ interface{}, err error) error {
+	return err
+}
@@ -0,0 +1,64 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"chainlink/core/store"
+	"chainlink/core/store/models/vrfkey"
+)
+
+var errMissingPublicKeyOrAll = errors.New(`must specify either "publicKey" or "all": true`)
+
+// VRFKeysController is the admin API surface for the node's in-memory VRF
+// keystore: it lets an authenticated operator lock keys remotely, without
+// needing to restart the node.
+type VRFKeysController struct {
+	App vrfKeyStoreApp
+}
+
+// vrfKeyStoreApp is the subset of the running chainlink.Application this
+// controller needs.
+type vrfKeyStoreApp interface {
+	GetStore() *store.Store
+}
+
+// RegisterRoutes adds this controller's routes to authenticated, the node's
+// authenticated admin API router group. The node's router setup is expected
+// to call this alongside its other controllers' route registration, the same
+// way it wires up e.g. the job and run controllers.
+func (vkc *VRFKeysController) RegisterRoutes(authenticated *gin.RouterGroup) {
+	authenticated.POST("/vrf_keys/forget", vkc.Forget)
+}
+
+// Forget handles POST /v2/vrf_keys/forget, locking the VRF key identified by
+// "publicKey" in the request body, or every VRF key if "all" is true.
+func (vkc *VRFKeysController) Forget(c *gin.Context) {
+	var request struct {
+		PublicKey string `json:"publicKey"`
+		All       bool   `json:"all"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	ks := vkc.App.GetStore().VRFKeyStore
+	if request.All {
+		ks.LockAll()
+		c.Status(http.StatusOK)
+		return
+	}
+	if request.PublicKey == "" {
+		c.AbortWithError(http.StatusBadRequest, errMissingPublicKeyOrAll)
+		return
+	}
+	publicKey, err := vrfkey.NewPublicKeyFromHex(request.PublicKey)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	ks.Lock(publicKey)
+	c.Status(http.StatusOK)
+}